@@ -0,0 +1,107 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// resolveARP resolves the hardware address of ip by broadcasting an ARP
+// request on dev and waiting for the matching reply
+func resolveARP(dev string, ip net.IP) (net.HardwareAddr, error) {
+	handle, err := pcap.OpenLive(dev, 1600, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("open device %s: %w", dev, err)
+	}
+	defer handle.Close()
+
+	err = handle.SetBPFFilter(fmt.Sprintf("arp and src host %s", ip.String()))
+	if err != nil {
+		return nil, fmt.Errorf("set bpf filter: %w", err)
+	}
+
+	localPacketSrc := gopacket.NewPacketSource(handle, handle.LinkType())
+	c := make(chan gopacket.Packet, 1)
+	go func() {
+		for packet := range localPacketSrc.Packets() {
+			c <- packet
+			break
+		}
+	}()
+	go func() {
+		time.Sleep(3 * time.Second)
+		c <- nil
+	}()
+
+	devs, err := FindAllDevs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("find all devices: %w", err)
+	}
+	var srcDev *Device
+	for _, d := range devs {
+		if d.Name == dev {
+			srcDev = d
+			break
+		}
+	}
+	if srcDev == nil {
+		return nil, fmt.Errorf("find device %s: %w", dev, errors.New("unknown"))
+	}
+	srcAddr := srcDev.IPv4Addr()
+	if srcAddr == nil {
+		return nil, fmt.Errorf("find device %s: %w", dev, errors.New("missing ipv4 address"))
+	}
+
+	err = sendARPRequest(handle, srcDev.HardwareAddr, srcAddr.IP, ip)
+	if err != nil {
+		return nil, fmt.Errorf("send arp request: %w", err)
+	}
+
+	packet := <-c
+	if packet == nil {
+		return nil, errors.New("timeout")
+	}
+	arpLayer := packet.Layer(layers.LayerTypeARP)
+	if arpLayer == nil {
+		return nil, fmt.Errorf("parse packet: %w", errors.New("missing arp layer"))
+	}
+	arp, ok := arpLayer.(*layers.ARP)
+	if !ok {
+		return nil, fmt.Errorf("parse packet: %w", errors.New("invalid"))
+	}
+	return net.HardwareAddr(arp.SourceHwAddress), nil
+}
+
+// sendARPRequest crafts and broadcasts an ARP request asking who owns targetIP
+func sendARPRequest(handle *pcap.Handle, srcMAC net.HardwareAddr, srcIP, targetIP net.IP) error {
+	eth := layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		EthernetType: layers.EthernetTypeARP,
+	}
+	arp := layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPRequest,
+		SourceHwAddress:   srcMAC,
+		SourceProtAddress: srcIP.To4(),
+		DstHwAddress:      net.HardwareAddr{0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		DstProtAddress:    targetIP.To4(),
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	err := gopacket.SerializeLayers(buf, opts, &eth, &arp)
+	if err != nil {
+		return fmt.Errorf("serialize packet: %w", err)
+	}
+
+	return handle.WritePacketData(buf.Bytes())
+}
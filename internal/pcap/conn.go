@@ -0,0 +1,11 @@
+package pcap
+
+import "github.com/google/gopacket"
+
+// PacketConn abstracts a datapath capable of reading and writing raw packets,
+// satisfied by both a live pcap.Handle and a TUN device
+type PacketConn interface {
+	ReadPacketData() (data []byte, ci gopacket.CaptureInfo, err error)
+	WritePacketData(data []byte) error
+	Close()
+}
@@ -5,21 +5,31 @@ import (
 	"fmt"
 	"ikago/internal/log"
 	"net"
-	"time"
 
-	"github.com/google/gopacket"
-	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcap"
-	"github.com/jackpal/gateway"
+)
+
+// DeviceKind describes the datapath backing a Device
+type DeviceKind int
+
+const (
+	// KindPcap indicates the device is captured and injected through pcap
+	KindPcap DeviceKind = iota
+	// KindTun indicates the device is a user-space TUN/TAP interface
+	KindTun
 )
 
 // Device describes an network device
 type Device struct {
 	Name         string
 	Alias        string
+	Kind         DeviceKind
 	IPAddrs      []*net.IPNet
 	HardwareAddr net.HardwareAddr
 	IsLoop       bool
+	MTU          int
+
+	conn PacketConn
 }
 
 // IPAddr returns the first IP address of the device
@@ -64,9 +74,12 @@ func (dev *Device) To4() *Device {
 	return &Device{
 		Name:         dev.Name,
 		Alias:        dev.Alias,
+		Kind:         dev.Kind,
 		IPAddrs:      addrs,
 		HardwareAddr: dev.HardwareAddr,
 		IsLoop:       dev.IsLoop,
+		MTU:          dev.MTU,
+		conn:         dev.conn,
 	}
 }
 
@@ -84,9 +97,12 @@ func (dev *Device) To16Only() *Device {
 	return &Device{
 		Name:         dev.Name,
 		Alias:        dev.Alias,
+		Kind:         dev.Kind,
 		IPAddrs:      addrs,
 		HardwareAddr: dev.HardwareAddr,
 		IsLoop:       dev.IsLoop,
+		MTU:          dev.MTU,
+		conn:         dev.conn,
 	}
 }
 
@@ -129,17 +145,91 @@ func (dev Device) AliasString() string {
 	return result
 }
 
+// Open returns a PacketConn for reading and writing packets through the
+// device, opening a live pcap capture for a KindPcap device or returning the
+// already-created handle for a KindTun device
+func (dev *Device) Open(snapLen int32, promisc bool) (PacketConn, error) {
+	switch dev.Kind {
+	case KindTun:
+		if dev.conn == nil {
+			return nil, fmt.Errorf("open device %s: %w", dev.Name, errors.New("tun device not initialized"))
+		}
+		return dev.conn, nil
+	default:
+		handle, err := pcap.OpenLive(dev.Name, snapLen, promisc, pcap.BlockForever)
+		if err != nil {
+			return nil, fmt.Errorf("open device %s: %w", dev.Name, err)
+		}
+		return handle, nil
+	}
+}
+
 const flagPcapLoopback = 1
 
-var blacklist map[string]bool
+// DeviceFilter constrains which devices FindAllDevs returns. The zero value
+// preserves FindAllDevs' historic behavior of returning every usable device
+type DeviceFilter struct {
+	// Blacklist excludes devices whose name or alias matches an entry
+	Blacklist []string
+	// Whitelist, if non-empty, restricts devices to those whose name or
+	// alias matches an entry
+	Whitelist []string
+	// SkipLoopback excludes loopback devices
+	SkipLoopback bool
+	// RequireIPv4 excludes devices without an IPv4 address
+	RequireIPv4 bool
+	// RequireIPv6 excludes devices without an IPv6 address
+	RequireIPv6 bool
+	// MinMTU excludes devices with a smaller MTU; ignored when <= 0
+	MinMTU int
+	// Predicate, if set, excludes any device for which it returns false
+	Predicate func(*Device) bool
+}
 
-// FindAllDevs returns all valid network devices in current computer
-func FindAllDevs() ([]*Device, error) {
+// matchesName reports whether dev's name or alias is present in names
+func matchesName(names []string, dev *Device) bool {
+	for _, name := range names {
+		if name == dev.Name || name == dev.Alias {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts reports whether dev passes the filter; a nil filter accepts everything
+func (filter *DeviceFilter) accepts(dev *Device) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.SkipLoopback && dev.IsLoop {
+		return false
+	}
+	if len(filter.Whitelist) > 0 && !matchesName(filter.Whitelist, dev) {
+		return false
+	}
+	if matchesName(filter.Blacklist, dev) {
+		return false
+	}
+	if filter.RequireIPv4 && dev.IPv4Addr() == nil {
+		return false
+	}
+	if filter.RequireIPv6 && dev.IPv6Addr() == nil {
+		return false
+	}
+	if filter.MinMTU > 0 && dev.MTU < filter.MinMTU {
+		return false
+	}
+	if filter.Predicate != nil && !filter.Predicate(dev) {
+		return false
+	}
+	return true
+}
+
+// FindAllDevs returns all network devices in the current computer accepted by filter
+func FindAllDevs(filter *DeviceFilter) ([]*Device, error) {
 	t := make([]*Device, 0)
 	result := make([]*Device, 0)
-	if blacklist == nil {
-		blacklist = make(map[string]bool)
-	}
+	autoBlacklist := make(map[string]bool)
 
 	// Enumerate system's network interfaces
 	inters, err := net.Interfaces()
@@ -174,7 +264,7 @@ func FindAllDevs() ([]*Device, error) {
 			as = append(as, ipnet)
 		}
 
-		t = append(t, &Device{Alias: inter.Name, IPAddrs: as, HardwareAddr: inter.HardwareAddr, IsLoop: isLoop})
+		t = append(t, &Device{Alias: inter.Name, IPAddrs: as, HardwareAddr: inter.HardwareAddr, IsLoop: isLoop, MTU: inter.MTU})
 	}
 
 	// Enumerate pcap devices
@@ -184,8 +274,8 @@ func FindAllDevs() ([]*Device, error) {
 		return nil, fmt.Errorf("find pcap devices: %w", err)
 	}
 	for _, dev := range devs {
-		// Check blacklist
-		_, ok := blacklist[dev.Name]
+		// Check auto blacklist
+		_, ok := autoBlacklist[dev.Name]
 		if ok {
 			continue
 		}
@@ -198,8 +288,8 @@ func FindAllDevs() ([]*Device, error) {
 			}
 			if d.Name != "" {
 				// return nil, errors.New("too many loopback devices")
-				blacklist[dev.Name] = true
-				blacklist[d.Name] = true
+				autoBlacklist[dev.Name] = true
+				autoBlacklist[d.Name] = true
 				log.Infof("Device %s is a loopback device but so is %s, these devices will not be used", dev.Name, d.Name)
 			}
 			d.Name = dev.Name
@@ -215,8 +305,8 @@ func FindAllDevs() ([]*Device, error) {
 				}
 				if d.Name != "" {
 					// return nil, fmt.Errorf("parse pcap device %s: %w", dev.Name, fmt.Errorf("same address with %s", d.Name))
-					blacklist[dev.Name] = true
-					blacklist[d.Name] = true
+					autoBlacklist[dev.Name] = true
+					autoBlacklist[d.Name] = true
 					log.Infof("Device %s has the same address with %s, these devices will not be used", dev.Name, d.Name)
 					break
 				}
@@ -227,14 +317,23 @@ func FindAllDevs() ([]*Device, error) {
 		}
 	}
 
-	// Check blacklist
+	// Check auto blacklist and user filter
 	for _, dev := range mid {
-		_, ok := blacklist[dev.Name]
-		if !ok {
+		_, ok := autoBlacklist[dev.Name]
+		if !ok && filter.accepts(dev) {
 			result = append(result, dev)
 		}
 	}
 
+	// Append TUN devices created by OpenTun
+	tunDevicesMu.Lock()
+	for _, dev := range tunDevices {
+		if filter.accepts(dev) {
+			result = append(result, dev)
+		}
+	}
+	tunDevicesMu.Unlock()
+
 	return result, nil
 }
 
@@ -262,71 +361,38 @@ func FindDev(devs []*Device, ip net.IP) *Device {
 
 // FindGatewayAddr returns the gateway's address
 func FindGatewayAddr() (*net.IPNet, error) {
-	ip, err := gateway.DiscoverGateway()
+	route, err := gatewayDiscoverer.DiscoverGateway()
 	if err != nil {
 		return nil, fmt.Errorf("discover gateway: %w", err)
 	}
-	return &net.IPNet{IP: ip}, nil
+	return &net.IPNet{IP: route.GatewayIP}, nil
 }
 
 // FindGatewayDev returns the gateway device
 func FindGatewayDev(dev string) (*Device, error) {
 	// Find gateway's IP
-	ip, err := gateway.DiscoverGateway()
+	route, err := gatewayDiscoverer.DiscoverGateway()
 	if err != nil {
 		return nil, fmt.Errorf("discover gateway: %w", err)
 	}
 
-	// Create a packet capture for testing
-	handle, err := pcap.OpenLive(dev, 1600, true, pcap.BlockForever)
-	if err != nil {
-		return nil, fmt.Errorf("open device %s: %w", dev, err)
-	}
-	err = handle.SetBPFFilter(fmt.Sprintf("udp and dst %s and dst port 65535", ip.String()))
-	if err != nil {
-		return nil, fmt.Errorf("set bpf filter: %w", err)
-	}
-	localPacketSrc := gopacket.NewPacketSource(handle, handle.LinkType())
-	c := make(chan gopacket.Packet, 1)
-	go func() {
-		for packet := range localPacketSrc.Packets() {
-			c <- packet
-			break
-		}
-	}()
-	go func() {
-		time.Sleep(3 * time.Second)
-		c <- nil
-	}()
-
-	// Attempt to send and capture a UDP packet
-	err = sendUDPPacket(ip.String()+":65535", []byte("0"))
+	// Resolve gateway's hardware address via the neighbor table, which
+	// consults the system's cached ARP/ND entries before probing actively
+	mac, err := SharedNeighborTable(dev).Lookup(route.GatewayIP)
 	if err != nil {
-		return nil, fmt.Errorf("send udp packet: %w", err)
+		return nil, fmt.Errorf("resolve gateway hardware address: %w", err)
 	}
 
-	// Analyze the packet and get gateway's hardware address
-	packet := <-c
-	if packet == nil {
-		return nil, errors.New("timeout")
-	}
-	ethernetLayer := packet.Layer(layers.LayerTypeEthernet)
-	if ethernetLayer == nil {
-		return nil, fmt.Errorf("parse packet: %w", errors.New("missing ethernet layer"))
-	}
-	ethernetPacket, ok := ethernetLayer.(*layers.Ethernet)
-	if !ok {
-		return nil, fmt.Errorf("parse packet: %w", errors.New("invalid"))
-	}
-	addrs := append(make([]*net.IPNet, 0), &net.IPNet{IP: ip})
-	return &Device{Alias: "Gateway", IPAddrs: addrs, HardwareAddr: ethernetPacket.DstMAC}, nil
+	addrs := append(make([]*net.IPNet, 0), &net.IPNet{IP: route.GatewayIP})
+	return &Device{Alias: "Gateway", IPAddrs: addrs, HardwareAddr: mac}, nil
 }
 
-// FindListenDevs returns all valid pcap devices for listening
-func FindListenDevs(devs []string) ([]*Device, error) {
+// FindListenDevs returns all valid pcap devices for listening, constrained
+// by filter (nil preserves the historic behavior of considering every device)
+func FindListenDevs(devs []string, filter *DeviceFilter) ([]*Device, error) {
 	result := make([]*Device, 0)
 
-	ds, err := FindAllDevs()
+	ds, err := FindAllDevs(filter)
 	if err != nil {
 		return nil, fmt.Errorf("find all devices: %w", err)
 	}
@@ -353,7 +419,7 @@ func FindListenDevs(devs []string) ([]*Device, error) {
 
 // FindUpstreamDevAndGatewayDev returns the pcap device for routing upstream and the gateway
 func FindUpstreamDevAndGatewayDev(dev string) (upDev, gatewayDev *Device, err error) {
-	devs, err := FindAllDevs()
+	devs, err := FindAllDevs(nil)
 	if err != nil {
 		return nil, nil, fmt.Errorf("find all devices: %w", err)
 	}
@@ -373,60 +439,55 @@ func FindUpstreamDevAndGatewayDev(dev string) (upDev, gatewayDev *Device, err er
 		if upDev.IsLoop {
 			gatewayDev = upDev
 		} else {
-			gatewayDev, err = FindGatewayDev(upDev.Name)
+			route, err := gatewayDiscoverer.DiscoverGateway()
+			if err != nil {
+				return nil, nil, fmt.Errorf("discover gateway: %w", err)
+			}
+			iface, err := net.InterfaceByName(upDev.Name)
+			if err != nil || iface.Index != route.IfaceIndex {
+				return nil, nil, fmt.Errorf("find gateway device: %w", fmt.Errorf("gateway is not reachable through upstream device %s", upDev.Alias))
+			}
+			if route.SourceIP == nil {
+				return nil, nil, fmt.Errorf("find gateway device: %w", errors.New("missing source address"))
+			}
+
+			mac, err := SharedNeighborTable(upDev.Name).Lookup(route.GatewayIP)
 			if err != nil {
-				return nil, nil, fmt.Errorf("find gateway device: %w", err)
+				return nil, nil, fmt.Errorf("resolve gateway hardware address: %w", err)
 			}
-			// Test if device's IP is in the same domain of the gateway's
-			var newUpDev *Device
+			gatewayAddrs := append(make([]*net.IPNet, 0), &net.IPNet{IP: route.GatewayIP})
+			gatewayDev = &Device{Alias: "Gateway", IPAddrs: gatewayAddrs, HardwareAddr: mac}
+
+			var srcAddr *net.IPNet
 			for _, addr := range upDev.IPAddrs {
-				if addr.Contains(gatewayDev.IPAddrs[0].IP) {
-					newUpDev = &Device{
-						Name:         upDev.Name,
-						Alias:        upDev.Alias,
-						IPAddrs:      append(make([]*net.IPNet, 0), addr),
-						HardwareAddr: upDev.HardwareAddr,
-						IsLoop:       upDev.IsLoop,
-					}
+				if addr.IP.Equal(route.SourceIP) {
+					srcAddr = addr
 					break
 				}
 			}
-			if newUpDev == nil {
-				return nil, nil, fmt.Errorf("find gateway device: %w", fmt.Errorf("different domain in upstream device %s and gateway", upDev.Alias))
+			if srcAddr == nil {
+				return nil, nil, fmt.Errorf("find gateway device: %w", fmt.Errorf("source address %s not found on upstream device %s", route.SourceIP, upDev.Alias))
+			}
+			upDev = &Device{
+				Name:         upDev.Name,
+				Alias:        upDev.Alias,
+				Kind:         upDev.Kind,
+				IPAddrs:      append(make([]*net.IPNet, 0), srcAddr),
+				HardwareAddr: upDev.HardwareAddr,
+				IsLoop:       upDev.IsLoop,
+				MTU:          upDev.MTU,
+				conn:         upDev.conn,
 			}
-			upDev = newUpDev
 		}
 	} else {
-		// Find upstream device and gateway
-		gatewayAddr, err := FindGatewayAddr()
+		// Find the best-ranked upstream candidate for the default route
+		candidates, err := FindUpstreamCandidates(nil)
 		if err != nil {
-			return nil, nil, fmt.Errorf("find gateway address: %w", err)
-		}
-		for _, d := range devs {
-			if d.IsLoop {
-				continue
-			}
-			// Test if device's IP is in the same domain of the gateway's
-			for _, addr := range d.IPAddrs {
-				if addr.Contains(gatewayAddr.IP) {
-					gatewayDev, err = FindGatewayDev(d.Name)
-					if err != nil {
-						continue
-					}
-					upDev = &Device{
-						Name:         d.Name,
-						Alias:        d.Alias,
-						IPAddrs:      append(make([]*net.IPNet, 0), addr),
-						HardwareAddr: d.HardwareAddr,
-						IsLoop:       d.IsLoop,
-					}
-					break
-				}
-			}
-			if upDev != nil {
-				break
-			}
+			return nil, nil, fmt.Errorf("find upstream candidates: %w", err)
 		}
+		best := candidates[0]
+		upDev = best.UpDev
+		gatewayDev = best.GatewayDev
 	}
 	return upDev, gatewayDev, nil
 }
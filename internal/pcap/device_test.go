@@ -0,0 +1,46 @@
+package pcap
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDeviceFilterAccepts(t *testing.T) {
+	loop := &Device{Name: "lo", Alias: "lo", IsLoop: true, MTU: 65536}
+	eth := &Device{
+		Name:  "eth0",
+		Alias: "eth0",
+		MTU:   1500,
+		IPAddrs: []*net.IPNet{
+			{IP: net.ParseIP("192.168.1.5"), Mask: net.CIDRMask(24, 32)},
+		},
+	}
+	vpn := &Device{Name: "tun0", Alias: "tun0", MTU: 1400}
+
+	tests := []struct {
+		name   string
+		filter *DeviceFilter
+		dev    *Device
+		want   bool
+	}{
+		{"nil filter accepts everything", nil, loop, true},
+		{"skip loopback excludes loop", &DeviceFilter{SkipLoopback: true}, loop, false},
+		{"skip loopback keeps non-loop", &DeviceFilter{SkipLoopback: true}, eth, true},
+		{"blacklist by name excludes", &DeviceFilter{Blacklist: []string{"tun0"}}, vpn, false},
+		{"whitelist excludes devices not listed", &DeviceFilter{Whitelist: []string{"eth0"}}, vpn, false},
+		{"whitelist keeps listed device", &DeviceFilter{Whitelist: []string{"eth0"}}, eth, true},
+		{"require ipv4 excludes device without one", &DeviceFilter{RequireIPv4: true}, vpn, false},
+		{"require ipv4 keeps device with one", &DeviceFilter{RequireIPv4: true}, eth, true},
+		{"min mtu excludes smaller mtu", &DeviceFilter{MinMTU: 1500}, vpn, false},
+		{"min mtu keeps equal mtu", &DeviceFilter{MinMTU: 1500}, eth, true},
+		{"predicate excludes when false", &DeviceFilter{Predicate: func(*Device) bool { return false }}, eth, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.accepts(tt.dev); got != tt.want {
+				t.Errorf("accepts(%s) = %v, want %v", tt.dev.Name, got, tt.want)
+			}
+		})
+	}
+}
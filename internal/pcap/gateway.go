@@ -0,0 +1,91 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jackpal/gateway"
+)
+
+// GatewayRoute describes a discovered default gateway
+type GatewayRoute struct {
+	// GatewayIP is the next hop's address
+	GatewayIP net.IP
+	// IfaceIndex is the index of the outgoing interface
+	IfaceIndex int
+	// SourceIP is the local address used to reach the gateway, if known
+	SourceIP net.IP
+}
+
+// GatewayDiscoverer finds the system's default gateway
+type GatewayDiscoverer interface {
+	DiscoverGateway() (*GatewayRoute, error)
+}
+
+var gatewayDiscoverer = newDefaultGatewayDiscoverer()
+
+// SetGatewayDiscoverer overrides the discoverer used by FindGatewayAddr and
+// FindGatewayDev. This exists for tests and headless environments where the
+// platform's usual route-query tooling is unavailable
+func SetGatewayDiscoverer(d GatewayDiscoverer) {
+	gatewayDiscoverer = d
+}
+
+// CommandDiscoverer discovers the default gateway using the jackpal/gateway
+// library, which parses the platform's route command output. It is not the
+// default on any platform, and the platform discoverers do not fall back to
+// it on failure; it is used only when installed with SetGatewayDiscoverer
+type CommandDiscoverer struct{}
+
+// DiscoverGateway implements GatewayDiscoverer
+func (CommandDiscoverer) DiscoverGateway() (*GatewayRoute, error) {
+	ip, err := gateway.DiscoverGateway()
+	if err != nil {
+		return nil, fmt.Errorf("discover gateway: %w", err)
+	}
+
+	devs, err := FindAllDevs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("find all devices: %w", err)
+	}
+	for _, d := range devs {
+		if d.IsLoop {
+			continue
+		}
+		for _, addr := range d.IPAddrs {
+			if !addr.Contains(ip) {
+				continue
+			}
+			iface, err := net.InterfaceByName(d.Name)
+			if err != nil {
+				continue
+			}
+			return &GatewayRoute{GatewayIP: ip, IfaceIndex: iface.Index, SourceIP: addr.IP}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("find interface for gateway %s: %w", ip, errors.New("not found"))
+}
+
+// findDeviceSourceIP returns the address of ifaceName suited for reaching
+// gatewayIP, or nil if none is known
+func findDeviceSourceIP(ifaceName string, gatewayIP net.IP) net.IP {
+	devs, err := FindAllDevs(nil)
+	if err != nil {
+		return nil
+	}
+	for _, d := range devs {
+		if d.Name != ifaceName {
+			continue
+		}
+		if gatewayIP.To4() != nil {
+			if addr := d.IPv4Addr(); addr != nil {
+				return addr.IP
+			}
+		} else if addr := d.IPv6Addr(); addr != nil {
+			return addr.IP
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// NetstatRouteDiscoverer discovers the default gateway by parsing
+// `netstat -rn` output (systemRoutes), the same data source
+// FindUpstreamCandidates uses
+type NetstatRouteDiscoverer struct{}
+
+// DiscoverGateway implements GatewayDiscoverer
+func (NetstatRouteDiscoverer) DiscoverGateway() (*GatewayRoute, error) {
+	routes, err := systemRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("find system routes: %w", err)
+	}
+	for _, route := range routes {
+		if route.Dest != nil || route.Gateway == nil {
+			continue
+		}
+		iface, err := net.InterfaceByName(route.Iface)
+		if err != nil {
+			continue
+		}
+		return &GatewayRoute{
+			GatewayIP:  route.Gateway,
+			IfaceIndex: iface.Index,
+			SourceIP:   findDeviceSourceIP(route.Iface, route.Gateway),
+		}, nil
+	}
+	return nil, errors.New("no default route found")
+}
+
+func newDefaultGatewayDiscoverer() GatewayDiscoverer {
+	return NetstatRouteDiscoverer{}
+}
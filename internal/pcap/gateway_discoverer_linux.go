@@ -0,0 +1,42 @@
+//go:build linux
+
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// NetlinkRouteDiscoverer discovers the default gateway from the kernel's
+// routing table (systemRoutes), the same data source FindUpstreamCandidates
+// uses; systemRoutes reads the table over netlink (RTM_GETROUTE) and only
+// falls back to parsing `ip route show` if the netlink socket is unavailable
+type NetlinkRouteDiscoverer struct{}
+
+// DiscoverGateway implements GatewayDiscoverer
+func (NetlinkRouteDiscoverer) DiscoverGateway() (*GatewayRoute, error) {
+	routes, err := systemRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("find system routes: %w", err)
+	}
+	for _, route := range routes {
+		if route.Dest != nil || route.Gateway == nil {
+			continue
+		}
+		iface, err := net.InterfaceByName(route.Iface)
+		if err != nil {
+			continue
+		}
+		return &GatewayRoute{
+			GatewayIP:  route.Gateway,
+			IfaceIndex: iface.Index,
+			SourceIP:   findDeviceSourceIP(route.Iface, route.Gateway),
+		}, nil
+	}
+	return nil, errors.New("no default route found")
+}
+
+func newDefaultGatewayDiscoverer() GatewayDiscoverer {
+	return NetlinkRouteDiscoverer{}
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// NetshRouteDiscoverer discovers the default gateway by parsing
+// `netsh interface ipv4/ipv6 show route` output (systemRoutes), the same
+// data source FindUpstreamCandidates uses
+type NetshRouteDiscoverer struct{}
+
+// DiscoverGateway implements GatewayDiscoverer
+func (NetshRouteDiscoverer) DiscoverGateway() (*GatewayRoute, error) {
+	routes, err := systemRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("find system routes: %w", err)
+	}
+	for _, route := range routes {
+		if route.Dest != nil || route.Gateway == nil {
+			continue
+		}
+		iface, err := net.InterfaceByName(route.Iface)
+		if err != nil {
+			continue
+		}
+		return &GatewayRoute{
+			GatewayIP:  route.Gateway,
+			IfaceIndex: iface.Index,
+			SourceIP:   findDeviceSourceIP(route.Iface, route.Gateway),
+		}, nil
+	}
+	return nil, errors.New("no default route found")
+}
+
+func newDefaultGatewayDiscoverer() GatewayDiscoverer {
+	return NetshRouteDiscoverer{}
+}
@@ -0,0 +1,139 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// solicitedNodeMulticastAddr returns the solicited-node multicast address
+// ff02::1:ffXX:XXXX for the given unicast IPv6 address
+func solicitedNodeMulticastAddr(ip net.IP) net.IP {
+	ip16 := ip.To16()
+	result := net.ParseIP("ff02::1:ff00:0000")
+	copy(result[13:], ip16[13:])
+	return result
+}
+
+// sendNeighborSolicitation crafts and sends an ICMPv6 Neighbor Solicitation
+// to the solicited-node multicast address of targetIP, asking who owns it
+func sendNeighborSolicitation(handle *pcap.Handle, srcMAC net.HardwareAddr, srcIP, targetIP net.IP) error {
+	dstIP := solicitedNodeMulticastAddr(targetIP)
+	dstMAC := net.HardwareAddr{0x33, 0x33, 0xff, dstIP[13], dstIP[14], dstIP[15]}
+
+	eth := layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       dstMAC,
+		EthernetType: layers.EthernetTypeIPv6,
+	}
+	ip6 := layers.IPv6{
+		Version:    6,
+		NextHeader: layers.IPProtocolICMPv6,
+		HopLimit:   255,
+		SrcIP:      srcIP,
+		DstIP:      dstIP,
+	}
+	icmp6 := layers.ICMPv6{
+		TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborSolicitation, 0),
+	}
+	err := icmp6.SetNetworkLayerForChecksum(&ip6)
+	if err != nil {
+		return fmt.Errorf("set network layer: %w", err)
+	}
+	ns := layers.ICMPv6NeighborSolicitation{
+		TargetAddress: targetIP,
+		Options: layers.ICMPv6Options{
+			{
+				Type: layers.ICMPv6OptSourceAddress,
+				Data: srcMAC,
+			},
+		},
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	err = gopacket.SerializeLayers(buf, opts, &eth, &ip6, &icmp6, &ns)
+	if err != nil {
+		return fmt.Errorf("serialize packet: %w", err)
+	}
+
+	return handle.WritePacketData(buf.Bytes())
+}
+
+// resolveND resolves the hardware address of ip by performing an ICMPv6
+// Neighbor Solicitation / Neighbor Advertisement exchange on dev
+func resolveND(dev string, ip net.IP) (net.HardwareAddr, error) {
+	handle, err := pcap.OpenLive(dev, 1600, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("open device %s: %w", dev, err)
+	}
+	defer handle.Close()
+
+	err = handle.SetBPFFilter(fmt.Sprintf("icmp6 and ip6[40] == 136 and src host %s", ip.String()))
+	if err != nil {
+		return nil, fmt.Errorf("set bpf filter: %w", err)
+	}
+
+	localPacketSrc := gopacket.NewPacketSource(handle, handle.LinkType())
+	c := make(chan gopacket.Packet, 1)
+	go func() {
+		for packet := range localPacketSrc.Packets() {
+			c <- packet
+			break
+		}
+	}()
+	go func() {
+		time.Sleep(3 * time.Second)
+		c <- nil
+	}()
+
+	// Find the up device's hardware address and its first IPv6 address (of
+	// any scope) to solicit from
+	devs, err := FindAllDevs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("find all devices: %w", err)
+	}
+	var srcDev *Device
+	for _, d := range devs {
+		if d.Name == dev {
+			srcDev = d
+			break
+		}
+	}
+	if srcDev == nil {
+		return nil, fmt.Errorf("find device %s: %w", dev, errors.New("unknown"))
+	}
+	srcAddr := srcDev.IPv6Addr()
+	if srcAddr == nil {
+		return nil, fmt.Errorf("find device %s: %w", dev, errors.New("missing ipv6 address"))
+	}
+
+	err = sendNeighborSolicitation(handle, srcDev.HardwareAddr, srcAddr.IP, ip)
+	if err != nil {
+		return nil, fmt.Errorf("send neighbor solicitation: %w", err)
+	}
+
+	packet := <-c
+	if packet == nil {
+		return nil, errors.New("timeout")
+	}
+	icmp6Layer := packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement)
+	if icmp6Layer == nil {
+		return nil, fmt.Errorf("parse packet: %w", errors.New("missing neighbor advertisement layer"))
+	}
+	na, ok := icmp6Layer.(*layers.ICMPv6NeighborAdvertisement)
+	if !ok {
+		return nil, fmt.Errorf("parse packet: %w", errors.New("invalid"))
+	}
+	for _, opt := range na.Options {
+		if opt.Type == layers.ICMPv6OptTargetAddress {
+			return net.HardwareAddr(opt.Data), nil
+		}
+	}
+	return nil, fmt.Errorf("parse packet: %w", errors.New("missing target link-layer address option"))
+}
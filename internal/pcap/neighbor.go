@@ -0,0 +1,101 @@
+package pcap
+
+import (
+	"fmt"
+	"ikago/internal/log"
+	"net"
+	"sync"
+	"time"
+)
+
+// neighborTTL is the duration a resolved or cached neighbor entry stays valid
+const neighborTTL = 5 * time.Minute
+
+type neighborEntry struct {
+	mac     net.HardwareAddr
+	expires time.Time
+}
+
+// NeighborTable caches IP-to-hardware-address resolutions reachable through
+// a device, seeded from the operating system's own neighbor tables and
+// refreshed by an active ARP request (IPv4) or ICMPv6 Neighbor Solicitation
+// (IPv6) on a cache miss
+type NeighborTable struct {
+	dev string
+
+	mu      sync.Mutex
+	entries map[string]neighborEntry
+	seeded  bool
+}
+
+// NewNeighborTable returns a neighbor table that resolves addresses reachable
+// through dev
+func NewNeighborTable(dev string) *NeighborTable {
+	return &NeighborTable{dev: dev, entries: make(map[string]neighborEntry)}
+}
+
+var (
+	neighborTablesMu sync.Mutex
+	neighborTables   = make(map[string]*NeighborTable)
+)
+
+// SharedNeighborTable returns the process-wide neighbor table for dev,
+// creating one on first use, so repeated lookups (e.g. from FindGatewayDev)
+// reuse the same cache instead of re-seeding and re-probing every call
+func SharedNeighborTable(dev string) *NeighborTable {
+	neighborTablesMu.Lock()
+	defer neighborTablesMu.Unlock()
+
+	t, ok := neighborTables[dev]
+	if !ok {
+		t = NewNeighborTable(dev)
+		neighborTables[dev] = t
+	}
+	return t
+}
+
+// Lookup returns the hardware address of ip, consulting the cache first and
+// falling back to an active probe on a miss or expiry
+func (t *NeighborTable) Lookup(ip net.IP) (net.HardwareAddr, error) {
+	t.mu.Lock()
+	if !t.seeded {
+		t.seed()
+	}
+	entry, ok := t.entries[ip.String()]
+	t.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.mac, nil
+	}
+
+	var mac net.HardwareAddr
+	var err error
+	if ip.To4() != nil {
+		mac, err = resolveARP(t.dev, ip)
+	} else {
+		mac, err = resolveND(t.dev, ip)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", ip, err)
+	}
+
+	t.mu.Lock()
+	t.entries[ip.String()] = neighborEntry{mac: mac, expires: time.Now().Add(neighborTTL)}
+	t.mu.Unlock()
+
+	return mac, nil
+}
+
+// seed populates the table from the operating system's neighbor tables;
+// callers must hold t.mu
+func (t *NeighborTable) seed() {
+	t.seeded = true
+	neighbors, err := systemNeighbors()
+	if err != nil {
+		log.Errorln(fmt.Errorf("seed neighbor table: %w", err))
+		return
+	}
+	expires := time.Now().Add(neighborTTL)
+	for ip, mac := range neighbors {
+		t.entries[ip] = neighborEntry{mac: mac, expires: expires}
+	}
+}
@@ -0,0 +1,48 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package pcap
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+)
+
+var (
+	arpEntryPattern = regexp.MustCompile(`\(([0-9.]+)\) at ([0-9a-fA-F:]+)`)
+	ndpEntryPattern = regexp.MustCompile(`(?m)^([0-9a-fA-F:]+)(?:%\S+)?\s+([0-9a-fA-F:]+)`)
+)
+
+// systemNeighbors reads the BSD/macOS ARP and NDP caches by shelling out to
+// `arp -an` and `ndp -an`
+func systemNeighbors() (map[string]net.HardwareAddr, error) {
+	result := make(map[string]net.HardwareAddr)
+
+	out, err := exec.Command("arp", "-an").Output()
+	if err != nil {
+		return nil, fmt.Errorf("run arp -an: %w", err)
+	}
+	for _, m := range arpEntryPattern.FindAllStringSubmatch(string(out), -1) {
+		mac, err := net.ParseMAC(m[2])
+		if err != nil {
+			continue
+		}
+		result[m[1]] = mac
+	}
+
+	out, err = exec.Command("ndp", "-an").Output()
+	if err != nil {
+		// ndp may be unavailable if IPv6 is disabled; IPv4 entries still stand
+		return result, nil
+	}
+	for _, m := range ndpEntryPattern.FindAllStringSubmatch(string(out), -1) {
+		mac, err := net.ParseMAC(m[2])
+		if err != nil {
+			continue
+		}
+		result[m[1]] = mac
+	}
+
+	return result, nil
+}
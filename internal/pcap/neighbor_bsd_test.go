@@ -0,0 +1,22 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package pcap
+
+import "testing"
+
+func TestNdpEntryPatternMatchesMultipleLines(t *testing.T) {
+	out := "Neighbor                             Linklayer Address  Netif Expire    St Flags\n" +
+		"fe80::1%en0                          aa:bb:cc:dd:ee:ff   en0   permanent R\n" +
+		"fe80::2%en0                          11:22:33:44:55:66   en0   12s      S\n"
+
+	matches := ndpEntryPattern.FindAllStringSubmatch(out, -1)
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2", len(matches))
+	}
+	if matches[0][1] != "fe80::1" {
+		t.Errorf("first match ip = %s, want fe80::1 (no %%zone suffix)", matches[0][1])
+	}
+	if matches[1][2] != "11:22:33:44:55:66" {
+		t.Errorf("second match mac = %s, want 11:22:33:44:55:66", matches[1][2])
+	}
+}
@@ -0,0 +1,59 @@
+//go:build linux
+
+package pcap
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// systemNeighbors reads Linux's cached IPv4 neighbors from /proc/net/arp and
+// IPv6 neighbors by shelling out to `ip neigh`, since ipv6 neighbors are not
+// exposed through a stable /proc file
+func systemNeighbors() (map[string]net.HardwareAddr, error) {
+	result := make(map[string]net.HardwareAddr)
+
+	f, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return nil, fmt.Errorf("open /proc/net/arp: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Skip header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		ip := fields[0]
+		mac, err := net.ParseMAC(fields[3])
+		if err != nil || mac.String() == "00:00:00:00:00:00" {
+			continue
+		}
+		result[ip] = mac
+	}
+
+	out, err := exec.Command("ip", "-6", "neigh").Output()
+	if err != nil {
+		// IPv6 may be disabled; IPv4 entries already gathered are still useful
+		return result, nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		mac, err := net.ParseMAC(fields[4])
+		if err != nil {
+			continue
+		}
+		result[fields[0]] = mac
+	}
+
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package pcap
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNeighborTableCacheHit(t *testing.T) {
+	table := NewNeighborTable("dev0")
+	want, err := net.ParseMAC("00:11:22:33:44:55")
+	if err != nil {
+		t.Fatalf("parse mac: %v", err)
+	}
+	table.seeded = true
+	table.entries["192.0.2.1"] = neighborEntry{mac: want, expires: time.Now().Add(neighborTTL)}
+
+	got, err := table.Lookup(net.ParseIP("192.0.2.1"))
+	if err != nil {
+		t.Fatalf("lookup cached entry: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("lookup = %s, want %s", got, want)
+	}
+}
+
+func TestSharedNeighborTableReusesInstance(t *testing.T) {
+	a := SharedNeighborTable("dev-shared-test")
+	b := SharedNeighborTable("dev-shared-test")
+	if a != b {
+		t.Error("SharedNeighborTable returned a new table for the same device")
+	}
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package pcap
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+)
+
+var (
+	arpEntryPatternWin = regexp.MustCompile(`([0-9]{1,3}(?:\.[0-9]{1,3}){3})\s+([0-9a-fA-F-]{17})`)
+	ndpEntryPatternWin = regexp.MustCompile(`([0-9a-fA-F:]*:[0-9a-fA-F:]+)\s+([0-9a-fA-F-]{17})`)
+)
+
+// systemNeighbors reads Windows's cached neighbor tables via `arp -a` for
+// IPv4 and `netsh interface ipv6 show neighbors` for IPv6
+func systemNeighbors() (map[string]net.HardwareAddr, error) {
+	result := make(map[string]net.HardwareAddr)
+
+	out, err := exec.Command("arp", "-a").Output()
+	if err != nil {
+		return nil, fmt.Errorf("run arp -a: %w", err)
+	}
+	for _, m := range arpEntryPatternWin.FindAllStringSubmatch(string(out), -1) {
+		mac, err := net.ParseMAC(m[2])
+		if err != nil {
+			continue
+		}
+		result[m[1]] = mac
+	}
+
+	out, err = exec.Command("netsh", "interface", "ipv6", "show", "neighbors").Output()
+	if err != nil {
+		// IPv6 neighbor table may be unavailable; IPv4 entries still stand
+		return result, nil
+	}
+	for _, m := range ndpEntryPatternWin.FindAllStringSubmatch(string(out), -1) {
+		mac, err := net.ParseMAC(m[2])
+		if err != nil {
+			continue
+		}
+		result[m[1]] = mac
+	}
+
+	return result, nil
+}
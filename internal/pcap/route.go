@@ -0,0 +1,129 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"ikago/internal/log"
+	"net"
+	"sort"
+)
+
+// systemRoute describes a single entry of the operating system's routing
+// table, as read by the platform's systemRoutes. On Linux this comes from a
+// netlink (RTM_GETROUTE) socket, falling back to parsing `ip route show`
+// only if netlink is unavailable; BSD and Windows still shell out to and
+// parse `netstat -rn`/`netsh ... show route`, so callers there inherit that
+// CLI dependency along with the data
+type systemRoute struct {
+	// Dest is the destination prefix, or nil for the default route
+	Dest *net.IPNet
+	// Gateway is the next hop, or nil for an on-link route
+	Gateway net.IP
+	// Iface is the name of the outgoing interface
+	Iface string
+	// Metric is the route's priority; lower wins
+	Metric int
+}
+
+// UpstreamRoute describes a candidate upstream path and the gateway reachable
+// through it
+type UpstreamRoute struct {
+	UpDev      *Device
+	GatewayDev *Device
+	Metric     int
+	Prefix     *net.IPNet
+}
+
+// prefixLen returns prefix's mask length, or 0 for the default route (a nil prefix)
+func prefixLen(prefix *net.IPNet) int {
+	if prefix == nil {
+		return 0
+	}
+	ones, _ := prefix.Mask.Size()
+	return ones
+}
+
+// rankUpstreamRoutes sorts routes in place by longest-prefix-match first,
+// falling back to metric only to break ties between equally-specific routes
+func rankUpstreamRoutes(routes []UpstreamRoute) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		li, lj := prefixLen(routes[i].Prefix), prefixLen(routes[j].Prefix)
+		if li != lj {
+			return li > lj
+		}
+		return routes[i].Metric < routes[j].Metric
+	})
+}
+
+// matchesDest reports whether route is eligible to carry traffic to dst; a
+// nil dst only matches the default route
+func (route *systemRoute) matchesDest(dst net.IP) bool {
+	if route.Dest == nil {
+		return true
+	}
+	if dst == nil {
+		return false
+	}
+	return route.Dest.Contains(dst)
+}
+
+// FindUpstreamCandidates enumerates the system's routes towards dst (or the
+// default route if dst is nil) and returns the matching (upDev, gatewayDev)
+// pairs ranked by longest-prefix-match, with metric as a tie-breaker; best first
+func FindUpstreamCandidates(dst net.IP) ([]UpstreamRoute, error) {
+	devs, err := FindAllDevs(nil)
+	if err != nil {
+		return nil, fmt.Errorf("find all devices: %w", err)
+	}
+	devByName := make(map[string]*Device)
+	for _, d := range devs {
+		devByName[d.Name] = d
+	}
+
+	routes, err := systemRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("find system routes: %w", err)
+	}
+
+	result := make([]UpstreamRoute, 0)
+	for _, route := range routes {
+		if !route.matchesDest(dst) {
+			continue
+		}
+
+		upDev, ok := devByName[route.Iface]
+		if !ok {
+			continue
+		}
+
+		var gatewayDev *Device
+		if upDev.IsLoop {
+			gatewayDev = upDev
+		} else if route.Gateway != nil {
+			mac, err := SharedNeighborTable(upDev.Name).Lookup(route.Gateway)
+			if err != nil {
+				log.Errorln(fmt.Errorf("resolve gateway %s on %s: %w", route.Gateway, upDev.Name, err))
+				continue
+			}
+			addrs := append(make([]*net.IPNet, 0), &net.IPNet{IP: route.Gateway})
+			gatewayDev = &Device{Alias: "Gateway", IPAddrs: addrs, HardwareAddr: mac}
+		} else {
+			gatewayDev = upDev
+		}
+
+		result = append(result, UpstreamRoute{
+			UpDev:      upDev,
+			GatewayDev: gatewayDev,
+			Metric:     route.Metric,
+			Prefix:     route.Dest,
+		})
+	}
+
+	rankUpstreamRoutes(result)
+
+	if len(result) <= 0 {
+		return nil, errors.New("no route found")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,52 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package pcap
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// systemRoutes parses the output of `netstat -rn`
+func systemRoutes() ([]systemRoute, error) {
+	result := make([]systemRoute, 0)
+
+	out, err := exec.Command("netstat", "-rn").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		dest, gateway, iface := fields[0], fields[1], fields[len(fields)-1]
+
+		route := systemRoute{Iface: iface}
+
+		if dest == "default" {
+			// route.Dest left nil to represent the default route
+		} else if _, prefix, err := net.ParseCIDR(dest); err == nil {
+			route.Dest = prefix
+		} else if ip := net.ParseIP(dest); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			route.Dest = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		} else {
+			continue
+		}
+
+		if ip := net.ParseIP(gateway); ip != nil {
+			route.Gateway = ip
+		}
+
+		result = append(result, route)
+	}
+
+	return result, nil
+}
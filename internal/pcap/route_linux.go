@@ -0,0 +1,70 @@
+//go:build linux
+
+package pcap
+
+import (
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// systemRoutes returns the kernel's IPv4 and IPv6 routing tables, read
+// directly over an AF_NETLINK/NETLINK_ROUTE socket (RTM_GETROUTE) so that
+// route lookups don't depend on the `ip` binary being present; if netlink
+// is unavailable (e.g. the socket is blocked by a restrictive sandbox), it
+// falls back to parsing `ip route show`/`ip -6 route show`
+func systemRoutes() ([]systemRoute, error) {
+	if routes, err := netlinkRoutes(); err == nil {
+		return routes, nil
+	}
+	return ipRouteShowRoutes()
+}
+
+// ipRouteShowRoutes parses the output of `ip route show` and `ip -6 route show`
+func ipRouteShowRoutes() ([]systemRoute, error) {
+	result := make([]systemRoute, 0)
+
+	for _, args := range [][]string{{"route", "show"}, {"-6", "route", "show"}} {
+		out, err := exec.Command("ip", args...).Output()
+		if err != nil {
+			// One address family may be disabled; the other listing still stands
+			continue
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			route := systemRoute{Metric: 0}
+
+			if fields[0] != "default" {
+				_, prefix, err := net.ParseCIDR(fields[0])
+				if err != nil {
+					continue
+				}
+				route.Dest = prefix
+			}
+
+			for i := 1; i < len(fields)-1; i++ {
+				switch fields[i] {
+				case "via":
+					route.Gateway = net.ParseIP(fields[i+1])
+				case "dev":
+					route.Iface = fields[i+1]
+				case "metric":
+					route.Metric, _ = strconv.Atoi(fields[i+1])
+				}
+			}
+
+			if route.Iface == "" {
+				continue
+			}
+
+			result = append(result, route)
+		}
+	}
+
+	return result, nil
+}
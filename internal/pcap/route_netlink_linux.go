@@ -0,0 +1,211 @@
+//go:build linux
+
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// rtnetlink route-dump constants (see rtnetlink(7) and linux/rtnetlink.h),
+// spelled out locally; their values match the syscall package's definitions
+const (
+	rtmNewRoute = 24 // RTM_NEWROUTE
+	rtmGetRoute = 26 // RTM_GETROUTE
+
+	nlmFRequest = 0x1
+	nlmFRoot    = 0x100
+	nlmFMatch   = 0x200
+	nlmFDump    = nlmFRoot | nlmFMatch
+
+	rtaDst      = 1
+	rtaOif      = 4
+	rtaGateway  = 5
+	rtaPriority = 6
+
+	rtTableMain = 254
+	rtnUnicast  = 1
+
+	nlmsgAlign  = 4
+	nlmsgHdrLen = 16
+	rtaHdrLen   = 4
+	rtMsgLen    = 12
+)
+
+// netlinkRoutes dumps the kernel's IPv4 and IPv6 routing tables (main table,
+// unicast routes only) over an AF_NETLINK/NETLINK_ROUTE socket
+func netlinkRoutes() ([]systemRoute, error) {
+	result := make([]systemRoute, 0)
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		routes, err := dumpNetlinkRoutes(family)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, routes...)
+	}
+	return result, nil
+}
+
+func dumpNetlinkRoutes(family uint8) ([]systemRoute, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("open netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("bind netlink socket: %w", err)
+	}
+
+	dst := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+	if err := syscall.Sendto(fd, newRouteDumpRequest(family), 0, dst); err != nil {
+		return nil, fmt.Errorf("send netlink route dump request: %w", err)
+	}
+
+	result := make([]systemRoute, 0)
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("read netlink response: %w", err)
+		}
+
+		done, routes, err := parseRouteDumpChunk(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, routes...)
+		if done {
+			break
+		}
+	}
+	return result, nil
+}
+
+// newRouteDumpRequest builds an RTM_GETROUTE dump request for family
+func newRouteDumpRequest(family uint8) []byte {
+	buf := make([]byte, nlmsgHdrLen+rtMsgLen)
+
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf))) // nlmsg_len
+	binary.LittleEndian.PutUint16(buf[4:6], rtmGetRoute)      // nlmsg_type
+	binary.LittleEndian.PutUint16(buf[6:8], nlmFRequest|nlmFDump)
+	binary.LittleEndian.PutUint32(buf[8:12], 1) // nlmsg_seq
+	// nlmsg_pid (buf[12:16]) left 0; the kernel fills in the sender's port
+
+	buf[nlmsgHdrLen] = family // rtm_family; remaining rtmsg fields are
+	// left 0 so the dump isn't filtered on table/scope/type
+	return buf
+}
+
+// parseRouteDumpChunk parses one recvfrom buffer's worth of netlink
+// messages, returning any routes found and whether NLMSG_DONE was reached
+func parseRouteDumpChunk(buf []byte) (done bool, routes []systemRoute, err error) {
+	for len(buf) >= nlmsgHdrLen {
+		msgLen := binary.LittleEndian.Uint32(buf[0:4])
+		msgType := binary.LittleEndian.Uint16(buf[4:6])
+		if int(msgLen) < nlmsgHdrLen || int(msgLen) > len(buf) {
+			return false, routes, fmt.Errorf("parse netlink message: invalid length %d", msgLen)
+		}
+		payload := buf[nlmsgHdrLen:msgLen]
+
+		switch msgType {
+		case syscall.NLMSG_DONE:
+			return true, routes, nil
+		case syscall.NLMSG_ERROR:
+			return false, routes, fmt.Errorf("netlink route dump returned an error")
+		case rtmNewRoute:
+			if route, ok := parseRtRoute(payload); ok {
+				routes = append(routes, route)
+			}
+		}
+
+		advance := alignTo(int(msgLen), nlmsgAlign)
+		if advance > len(buf) {
+			break
+		}
+		buf = buf[advance:]
+	}
+	return false, routes, nil
+}
+
+// parseRtRoute decodes a single RTM_NEWROUTE payload (an rtmsg followed by
+// rtattrs) into a systemRoute, keeping only unicast routes in the main table
+func parseRtRoute(payload []byte) (systemRoute, bool) {
+	if len(payload) < rtMsgLen {
+		return systemRoute{}, false
+	}
+
+	family := payload[0]
+	dstLen := payload[1]
+	table := payload[4]
+	kind := payload[7]
+
+	if table != rtTableMain || kind != rtnUnicast {
+		return systemRoute{}, false
+	}
+
+	route := systemRoute{Metric: 0}
+	if dstLen > 0 {
+		route.Dest = &net.IPNet{Mask: net.CIDRMask(int(dstLen), addrFamilyBits(family))}
+	}
+
+	attrs := payload[rtMsgLen:]
+	for len(attrs) >= rtaHdrLen {
+		attrLen := binary.LittleEndian.Uint16(attrs[0:2])
+		attrType := binary.LittleEndian.Uint16(attrs[2:4])
+		if int(attrLen) < rtaHdrLen || int(attrLen) > len(attrs) {
+			break
+		}
+		data := attrs[rtaHdrLen:attrLen]
+
+		switch attrType {
+		case rtaDst:
+			if route.Dest != nil {
+				route.Dest.IP = append(net.IP(nil), data...)
+			}
+		case rtaGateway:
+			route.Gateway = append(net.IP(nil), data...)
+		case rtaOif:
+			if len(data) >= 4 {
+				if iface, err := net.InterfaceByIndex(int(binary.LittleEndian.Uint32(data))); err == nil {
+					route.Iface = iface.Name
+				}
+			}
+		case rtaPriority:
+			if len(data) >= 4 {
+				route.Metric = int(binary.LittleEndian.Uint32(data))
+			}
+		}
+
+		advance := alignTo(int(attrLen), nlmsgAlign)
+		if advance > len(attrs) {
+			break
+		}
+		attrs = attrs[advance:]
+	}
+
+	if route.Iface == "" {
+		return systemRoute{}, false
+	}
+	if route.Dest != nil && route.Dest.IP == nil {
+		// dstLen > 0 but no RTA_DST attribute; the prefix can't be trusted
+		return systemRoute{}, false
+	}
+	return route, true
+}
+
+func addrFamilyBits(family uint8) int {
+	if family == syscall.AF_INET6 {
+		return 128
+	}
+	return 32
+}
+
+func alignTo(n, align int) int {
+	if rem := n % align; rem != 0 {
+		return n + align - rem
+	}
+	return n
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package pcap
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+)
+
+// rtRouteFixture builds a raw RTM_NEWROUTE payload (rtmsg plus rtattrs) for
+// parseRtRoute tests
+func rtRouteFixture(t *testing.T, table, kind, dstLen byte, attrs map[uint16][]byte) []byte {
+	t.Helper()
+
+	buf := make([]byte, rtMsgLen)
+	buf[0] = byte(syscall.AF_INET)
+	buf[1] = dstLen
+	buf[4] = table
+	buf[7] = kind
+
+	for typ, val := range attrs {
+		attr := make([]byte, rtaHdrLen+len(val))
+		binary.LittleEndian.PutUint16(attr[0:2], uint16(len(attr)))
+		binary.LittleEndian.PutUint16(attr[2:4], typ)
+		copy(attr[rtaHdrLen:], val)
+		for len(attr)%nlmsgAlign != 0 {
+			attr = append(attr, 0)
+		}
+		buf = append(buf, attr...)
+	}
+	return buf
+}
+
+func TestParseRtRouteAcceptsMainTableUnicast(t *testing.T) {
+	loopback, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("no loopback interface available: %v", err)
+	}
+
+	oif := make([]byte, 4)
+	binary.LittleEndian.PutUint32(oif, uint32(loopback.Index))
+	priority := make([]byte, 4)
+	binary.LittleEndian.PutUint32(priority, 100)
+
+	payload := rtRouteFixture(t, rtTableMain, rtnUnicast, 0, map[uint16][]byte{
+		rtaOif:      oif,
+		rtaPriority: priority,
+	})
+
+	route, ok := parseRtRoute(payload)
+	if !ok {
+		t.Fatal("parseRtRoute rejected a main-table unicast route")
+	}
+	if route.Iface != loopback.Name {
+		t.Errorf("route.Iface = %s, want %s", route.Iface, loopback.Name)
+	}
+	if route.Metric != 100 {
+		t.Errorf("route.Metric = %d, want 100", route.Metric)
+	}
+	if route.Dest != nil {
+		t.Errorf("route.Dest = %v, want nil (default route)", route.Dest)
+	}
+}
+
+func TestParseRtRouteRejectsOtherTables(t *testing.T) {
+	oif := make([]byte, 4)
+	binary.LittleEndian.PutUint32(oif, 1)
+
+	payload := rtRouteFixture(t, 255, rtnUnicast, 0, map[uint16][]byte{rtaOif: oif})
+
+	if _, ok := parseRtRoute(payload); ok {
+		t.Error("parseRtRoute accepted a route outside the main table")
+	}
+}
+
+func TestParseRtRouteRejectsMissingInterface(t *testing.T) {
+	payload := rtRouteFixture(t, rtTableMain, rtnUnicast, 0, nil)
+
+	if _, ok := parseRtRoute(payload); ok {
+		t.Error("parseRtRoute accepted a route with no resolvable interface")
+	}
+}
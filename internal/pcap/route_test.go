@@ -0,0 +1,47 @@
+package pcap
+
+import (
+	"net"
+	"testing"
+)
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, prefix, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("parse cidr %s: %v", s, err)
+	}
+	return prefix
+}
+
+func TestRankUpstreamRoutesPrefersLongestPrefix(t *testing.T) {
+	wifi := &Device{Name: "wifi0"}
+	cellular := &Device{Name: "wwan0"}
+
+	routes := []UpstreamRoute{
+		{UpDev: wifi, Metric: 0, Prefix: nil},
+		{UpDev: cellular, Metric: 600, Prefix: mustCIDR(t, "10.0.0.0/8")},
+	}
+
+	rankUpstreamRoutes(routes)
+
+	if routes[0].UpDev != cellular {
+		t.Errorf("best route upDev = %s, want the more specific 10.0.0.0/8 route via %s", routes[0].UpDev.Name, cellular.Name)
+	}
+}
+
+func TestRankUpstreamRoutesTieBreaksOnMetric(t *testing.T) {
+	a := &Device{Name: "eth0"}
+	b := &Device{Name: "eth1"}
+
+	routes := []UpstreamRoute{
+		{UpDev: a, Metric: 200, Prefix: nil},
+		{UpDev: b, Metric: 100, Prefix: nil},
+	}
+
+	rankUpstreamRoutes(routes)
+
+	if routes[0].UpDev != b {
+		t.Errorf("best route upDev = %s, want the lower-metric route via %s", routes[0].UpDev.Name, b.Name)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package pcap
+
+import (
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// systemRoutes parses the output of `netsh interface ipv4 show route` and
+// `netsh interface ipv6 show route`
+func systemRoutes() ([]systemRoute, error) {
+	result := make([]systemRoute, 0)
+
+	for _, args := range [][]string{
+		{"interface", "ipv4", "show", "route"},
+		{"interface", "ipv6", "show", "route"},
+	} {
+		out, err := exec.Command("netsh", args...).Output()
+		if err != nil {
+			continue
+		}
+
+		lines := strings.Split(string(out), "\n")
+		for i, line := range lines {
+			if i < 3 {
+				// Skip the header rows
+				continue
+			}
+			// Columns: Publish, Type, Met, Prefix, Idx, Gateway/Interface Name
+			fields := strings.Fields(line)
+			if len(fields) < 6 {
+				continue
+			}
+
+			metric, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+
+			// The "Idx" column is a numeric interface index, not a name;
+			// resolve it so callers can key off Device.Name like on every
+			// other platform
+			idx, err := strconv.Atoi(fields[4])
+			if err != nil {
+				continue
+			}
+			iface, err := net.InterfaceByIndex(idx)
+			if err != nil {
+				continue
+			}
+
+			route := systemRoute{Metric: metric, Iface: iface.Name}
+
+			if fields[3] == "0.0.0.0/0" || fields[3] == "::/0" {
+				// route.Dest left nil to represent the default route
+			} else if _, prefix, err := net.ParseCIDR(fields[3]); err == nil {
+				route.Dest = prefix
+			} else {
+				continue
+			}
+
+			if ip := net.ParseIP(fields[5]); ip != nil {
+				route.Gateway = ip
+			}
+
+			result = append(result, route)
+		}
+	}
+
+	return result, nil
+}
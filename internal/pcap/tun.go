@@ -0,0 +1,75 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/google/gopacket"
+	"github.com/songgao/water"
+)
+
+var (
+	tunDevicesMu sync.Mutex
+	tunDevices   = make(map[string]*Device)
+)
+
+// tunConn adapts a TUN interface to the PacketConn interface
+type tunConn struct {
+	iface *water.Interface
+}
+
+func (c *tunConn) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	buf := make([]byte, 65536)
+	n, err := c.iface.Read(buf)
+	if err != nil {
+		return nil, gopacket.CaptureInfo{}, fmt.Errorf("read tun: %w", err)
+	}
+	return buf[:n], gopacket.CaptureInfo{CaptureLength: n, Length: n}, nil
+}
+
+func (c *tunConn) WritePacketData(data []byte) error {
+	_, err := c.iface.Write(data)
+	return err
+}
+
+func (c *tunConn) Close() {
+	_ = c.iface.Close()
+}
+
+// OpenTun creates a TUN device named name, assigns it mtu and addrs, and
+// returns a Device backed by the interface
+func OpenTun(name string, mtu int, addrs []*net.IPNet) (*Device, error) {
+	if len(addrs) <= 0 {
+		return nil, fmt.Errorf("open tun device %s: %w", name, errors.New("missing address"))
+	}
+
+	config := water.Config{DeviceType: water.TUN}
+	config.Name = name
+	iface, err := water.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("create tun device %s: %w", name, err)
+	}
+
+	err = configureTunDevice(iface.Name(), mtu, addrs)
+	if err != nil {
+		_ = iface.Close()
+		return nil, fmt.Errorf("configure tun device %s: %w", iface.Name(), err)
+	}
+
+	dev := &Device{
+		Name:    iface.Name(),
+		Alias:   iface.Name(),
+		Kind:    KindTun,
+		IPAddrs: addrs,
+		MTU:     mtu,
+		conn:    &tunConn{iface: iface},
+	}
+
+	tunDevicesMu.Lock()
+	tunDevices[dev.Name] = dev
+	tunDevicesMu.Unlock()
+
+	return dev, nil
+}
@@ -0,0 +1,39 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package pcap
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+)
+
+// configureTunDevice brings up name, sets its MTU and assigns addrs using
+// ifconfig(8)
+func configureTunDevice(name string, mtu int, addrs []*net.IPNet) error {
+	for _, addr := range addrs {
+		args := []string{name, addr.IP.String(), addr.IP.String()}
+		if addr.IP.To4() == nil {
+			args = []string{name, "inet6", addr.String()}
+		}
+		err := exec.Command("ifconfig", args...).Run()
+		if err != nil {
+			return fmt.Errorf("add address %s: %w", addr, err)
+		}
+	}
+
+	if mtu > 0 {
+		err := exec.Command("ifconfig", name, "mtu", strconv.Itoa(mtu)).Run()
+		if err != nil {
+			return fmt.Errorf("set mtu: %w", err)
+		}
+	}
+
+	err := exec.Command("ifconfig", name, "up").Run()
+	if err != nil {
+		return fmt.Errorf("bring up device: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package pcap
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+)
+
+// configureTunDevice brings up name, sets its MTU and assigns addrs using the
+// ip(8) utility
+func configureTunDevice(name string, mtu int, addrs []*net.IPNet) error {
+	if mtu > 0 {
+		err := exec.Command("ip", "link", "set", "dev", name, "mtu", strconv.Itoa(mtu)).Run()
+		if err != nil {
+			return fmt.Errorf("set mtu: %w", err)
+		}
+	}
+
+	for _, addr := range addrs {
+		err := exec.Command("ip", "addr", "add", addr.String(), "dev", name).Run()
+		if err != nil {
+			return fmt.Errorf("add address %s: %w", addr, err)
+		}
+	}
+
+	err := exec.Command("ip", "link", "set", "dev", name, "up").Run()
+	if err != nil {
+		return fmt.Errorf("bring up device: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package pcap
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+)
+
+// configureTunDevice sets name's MTU and assigns addrs using netsh
+func configureTunDevice(name string, mtu int, addrs []*net.IPNet) error {
+	for _, addr := range addrs {
+		ones, _ := addr.Mask.Size()
+		var err error
+		if addr.IP.To4() != nil {
+			err = exec.Command("netsh", "interface", "ipv4", "add", "address", name, addr.IP.String(), fmt.Sprintf("%d", ones)).Run()
+		} else {
+			err = exec.Command("netsh", "interface", "ipv6", "add", "address", name, addr.String()).Run()
+		}
+		if err != nil {
+			return fmt.Errorf("add address %s: %w", addr, err)
+		}
+	}
+
+	if mtu > 0 {
+		err := exec.Command("netsh", "interface", "ipv4", "set", "subinterface", name, "mtu="+strconv.Itoa(mtu), "store=persistent").Run()
+		if err != nil {
+			return fmt.Errorf("set mtu: %w", err)
+		}
+	}
+
+	return nil
+}